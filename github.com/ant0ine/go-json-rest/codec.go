@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Codec knows how to encode/decode entities for one particular media
+// type, so ResponseWriter.WriteEntity and Request.ReadEntity can support
+// more than just JSON.
+type Codec interface {
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+	MediaType() string
+}
+
+const jsonMediaType = "application/json"
+const xmlMediaType = "application/xml"
+
+// DefaultCodecs is used by ResourceHandler when Codecs is left empty.
+var DefaultCodecs = []Codec{&jsonCodec{}, &xmlCodec{}}
+
+type jsonCodec struct {
+	indent bool
+}
+
+func (self *jsonCodec) MediaType() string { return jsonMediaType }
+
+func (self *jsonCodec) Encode(w io.Writer, v interface{}) error {
+	if self.indent {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (self *jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type xmlCodec struct{}
+
+func (self *xmlCodec) MediaType() string { return xmlMediaType }
+
+func (self *xmlCodec) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (self *xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+type acceptEntry struct {
+	mediaType string
+	quality   float64
+}
+
+type byQualityDesc []acceptEntry
+
+func (self byQualityDesc) Len() int           { return len(self) }
+func (self byQualityDesc) Less(i, j int) bool { return self[i].quality > self[j].quality }
+func (self byQualityDesc) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// Parse a header like "application/xml;q=0.9, application/json", sorted by
+// decreasing quality, stable on ties so the original order is preserved.
+func parseAccept(header string) []acceptEntry {
+	if header == "" {
+		return nil
+	}
+
+	entries := []acceptEntry{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(strings.TrimSpace(part), ";")
+		if params[0] == "" {
+			continue
+		}
+
+		entry := acceptEntry{mediaType: strings.TrimSpace(params[0]), quality: 1.0}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					entry.quality = q
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Stable(byQualityDesc(entries))
+	return entries
+}
+
+func mediaTypeMatches(mediaType, accept string) bool {
+	if accept == "*/*" || accept == mediaType {
+		return true
+	}
+	if strings.HasSuffix(accept, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(accept, "*"))
+	}
+	return false
+}
+
+// Pick the Codec among codecs best matching header (an Accept or
+// Content-Type value), honoring quality values. Returns nil when header is
+// set but none of the codecs match, and codecs[0] when header is empty.
+func negotiateCodec(header string, codecs []Codec) Codec {
+	entries := parseAccept(header)
+	if len(entries) == 0 {
+		return codecs[0]
+	}
+
+	for _, entry := range entries {
+		if entry.quality <= 0 {
+			continue
+		}
+		for _, codec := range codecs {
+			if mediaTypeMatches(codec.MediaType(), entry.mediaType) {
+				return codec
+			}
+		}
+	}
+
+	return nil
+}
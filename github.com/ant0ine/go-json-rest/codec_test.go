@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"launchpad.net/gocheck"
+	"net/http"
+
+	"github.com/ant0ine/go-json-rest/test"
+)
+
+type CodecSuite struct{}
+
+var _ = gocheck.Suite(&CodecSuite{})
+
+type codecPayload struct {
+	Id   string
+	Name string
+}
+
+func (s *CodecSuite) TestWriteEntityXml(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/users/:id",
+		Func: func(w *ResponseWriter, r *Request) {
+			w.WriteEntity(&codecPayload{Id: r.PathParam("id"), Name: "Antoine"})
+		},
+	})
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/users/42", nil)
+	request.Header.Set("Accept", "application/xml")
+	recorded := test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+	recorded.HeaderIs(c, "Content-Type", "application/xml; charset=utf-8")
+
+	var payload codecPayload
+	err := xml.Unmarshal(recorded.Recorder.Body.Bytes(), &payload)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(payload.Id, gocheck.Equals, "42")
+	c.Assert(payload.Name, gocheck.Equals, "Antoine")
+}
+
+func (s *CodecSuite) TestReadEntityXml(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "POST",
+		PathExp:    "/users",
+		Func: func(w *ResponseWriter, r *Request) {
+			var payload codecPayload
+			err := r.ReadEntity(&payload)
+			c.Assert(err, gocheck.IsNil)
+			w.WriteJson(&payload)
+		},
+	})
+
+	b, err := xml.Marshal(&codecPayload{Id: "42", Name: "Antoine"})
+	c.Assert(err, gocheck.IsNil)
+
+	request, err := http.NewRequest("POST", "http://example.com/users", bytes.NewReader(b))
+	c.Assert(err, gocheck.IsNil)
+	request.Header.Set("Content-Type", "application/xml")
+	request.Header.Set("Accept", "application/json")
+
+	recorded := test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+
+	var decoded codecPayload
+	err = recorded.DecodeJsonPayload(&decoded)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(decoded.Id, gocheck.Equals, "42")
+	c.Assert(decoded.Name, gocheck.Equals, "Antoine")
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// JSON body written for every error response, so a REST client never has
+// to special-case a text/plain 404/405/500 among its JSON responses.
+type ErrorResponse struct {
+	Error   string
+	Code    int
+	Details map[string]interface{} `json:",omitempty"`
+}
+
+// Key used in Request.Env by RecoverMiddleware to stash the panic's stack
+// trace, picked up by defaultErrorHandler to populate Details["stack"].
+const envStackTrace = "STACK_TRACE"
+
+// Write error as an ErrorResponse, encoded with whichever Codec was
+// negotiated for the response. This is a convenience function for the
+// common case, use the ResponseWriter directly for anything more custom.
+// Unlike ResourceHandler.ErrorHandler, it is not aware of any particular
+// ResourceHandler instance, and never attaches a stack trace to Details.
+func Error(w *ResponseWriter, error string, code int) {
+	defaultErrorHandler(w, nil, errors.New(error), code)
+}
+
+// Return a 404 Not Found error response.
+func NotFound(w *ResponseWriter, r *Request) {
+	defaultErrorHandler(w, r, fmt.Errorf("Resource not found"), http.StatusNotFound)
+}
+
+// The error writer used when ResourceHandler.ErrorHandler is not set, and
+// the one responsible for attaching Details["stack"] when available.
+func defaultErrorHandler(w *ResponseWriter, r *Request, err error, code int) {
+	response := &ErrorResponse{
+		Error: err.Error(),
+		Code:  code,
+	}
+	if r != nil {
+		if stack, ok := r.Env[envStackTrace].(string); ok {
+			response.Details = map[string]interface{}{"stack": stack}
+		}
+	}
+	w.WriteHeader(code)
+	w.WriteEntity(response)
+}
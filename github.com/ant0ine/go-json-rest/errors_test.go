@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"launchpad.net/gocheck"
+	"strings"
+
+	"github.com/ant0ine/go-json-rest/test"
+)
+
+type ErrorsSuite struct{}
+
+var _ = gocheck.Suite(&ErrorsSuite{})
+
+func (s *ErrorsSuite) TestDefaultErrorHandlerResponse(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/boom",
+		Func: func(w *ResponseWriter, r *Request) {
+			panic("kaboom")
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/boom", nil))
+	recorded.CodeIs(c, 500)
+
+	var payload ErrorResponse
+	err := recorded.DecodeJsonPayload(&payload)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(payload.Error, gocheck.Equals, "kaboom")
+	c.Assert(payload.Code, gocheck.Equals, 500)
+	c.Assert(payload.Details, gocheck.IsNil)
+}
+
+func (s *ErrorsSuite) TestStackTraceInDetails(c *gocheck.C) {
+	handler := ResourceHandler{EnableResponseStackTrace: true}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/boom",
+		Func: func(w *ResponseWriter, r *Request) {
+			panic("kaboom")
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/boom", nil))
+	recorded.CodeIs(c, 500)
+
+	var payload ErrorResponse
+	err := recorded.DecodeJsonPayload(&payload)
+	c.Assert(err, gocheck.IsNil)
+
+	stack, ok := payload.Details["stack"].(string)
+	c.Assert(ok, gocheck.Equals, true)
+	c.Assert(strings.Contains(stack, "kaboom"), gocheck.Equals, true)
+}
+
+func (s *ErrorsSuite) TestCustomErrorHandler(c *gocheck.C) {
+	called := false
+	handler := ResourceHandler{
+		DisableJsonIndent: true,
+		ErrorHandler: func(w *ResponseWriter, r *Request, err error, code int) {
+			called = true
+			w.WriteHeader(code)
+			w.WriteJson(map[string]string{"custom": err.Error()})
+		},
+	}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/boom",
+		Func: func(w *ResponseWriter, r *Request) {
+			panic("kaboom")
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/boom", nil))
+	recorded.CodeIs(c, 500)
+	c.Assert(called, gocheck.Equals, true)
+	recorded.BodyIs(c, "{\"custom\":\"kaboom\"}")
+}
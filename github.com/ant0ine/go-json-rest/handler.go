@@ -46,24 +46,34 @@
 package rest
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"reflect"
-	"runtime/debug"
-	"strings"
+	"sync"
 	"time"
 )
 
 // Implement the http.Handler interface and act as a router for the defined Routes.
 // The defaults are intended to be developemnt friendly, for production you may want
 // to turn on gzip and disable the JSON indentation.
+//
+// Internally, the built-in behaviors (panic recovery, gzip, JSON indentation, the
+// status service and access logging) are implemented as Middlewares, assembled into
+// a stack by buildHandlerStack. PreRoutingMiddlewares and Route.Middlewares let users
+// insert their own Middlewares (auth, rate-limiting, tracing, ...) into that stack
+// without forking this handler.
 type ResourceHandler struct {
 	internalRouter *router
 	statusService  *statusService
 
+	// Guards shuttingDown and shutdownSignal, see Shutdown.
+	mu             sync.Mutex
+	shuttingDown   bool
+	shutdownSignal chan struct{}
+	activeRequests sync.WaitGroup
+
 	// If true, and if the client accepts the Gzip encoding, the response payloads
 	// will be compressed using gzip, and the corresponding response header will set.
 	EnableGzip bool
@@ -85,6 +95,36 @@ type ResourceHandler struct {
 
 	// Custom logger, defaults to log.New(os.Stderr, "", log.LstdFlags)
 	Logger *log.Logger
+
+	// Middlewares wrapped around the routing itself, so they run for every
+	// request, including the ones that never reach a matched Route (404,
+	// 405, 406 Not Acceptable, 503 Service Unavailable during Shutdown),
+	// but before any per-Route Middlewares. They sit inside the built-in
+	// Recover/AccessLog/Gzip/JsonIndent middlewares assembled by
+	// buildHandlerStack.
+	PreRoutingMiddlewares []Middleware
+
+	// Called instead of defaultErrorHandler to render 404, 405 and panic
+	// (500) responses. Left nil, these keep emitting a JSON ErrorResponse.
+	ErrorHandler func(*ResponseWriter, *Request, error, int)
+
+	// Codecs available for content negotiation, matched against the Accept
+	// and Content-Type headers. Defaults to DefaultCodecs (JSON and XML)
+	// when left empty.
+	Codecs []Codec
+
+	// If non-zero, installed as a deadline on every request's Context,
+	// cancelling it once the handler has run for that long.
+	HandlerTimeout time.Duration
+}
+
+// Render err/code through ErrorHandler if set, or defaultErrorHandler otherwise.
+func (self *ResourceHandler) handleError(w *ResponseWriter, r *Request, err error, code int) {
+	if self.ErrorHandler != nil {
+		self.ErrorHandler(w, r, err, code)
+		return
+	}
+	defaultErrorHandler(w, r, err, code)
 }
 
 // Used with SetRoutes.
@@ -95,13 +135,23 @@ type Route struct {
 
 	// A string like "/resource/:id.json".
 	// Placeholders supported are:
-	// :param that matches any char to the first '/' or '.'
+	// :param that matches any char to the first '/' or '.'; a literal
+	// suffix after the '.', like ".json" above, must then match too, and
+	// is not included in the extracted PathParam
 	// *splat that matches everything to the end of the string
 	// (placeholder names should be unique per PathExp)
 	PathExp string
 
 	// Code that will be executed when this route is taken.
 	Func func(*ResponseWriter, *Request)
+
+	// Middlewares wrapped around Func, innermost first, so they only run
+	// when this particular Route is matched.
+	Middlewares []Middleware
+
+	// Optional name, unique among the Routes of a ResourceHandler, letting
+	// Request.URLFor reverse-resolve this Route's path.
+	Name string
 }
 
 // Create a Route that points to an object method. It can be convenient to point to an object method instead
@@ -163,141 +213,142 @@ func (self *ResourceHandler) SetRoutes(routes ...Route) error {
 	return nil
 }
 
-type responseLogRecord struct {
-	StatusCode   int
-	ResponseTime *time.Duration
-	HttpMethod   string
-	RequestURI   string
-}
+// Assemble the Middleware stack that wraps every request: the built-in
+// behaviors driven by the ResourceHandler fields on the outside, the
+// PreRoutingMiddlewares around the routing decision, and finally dispatch,
+// which looks up the Route and applies its own Route.Middlewares.
+func (self *ResourceHandler) buildHandlerStack() HandlerFunc {
+
+	middlewares := []Middleware{
+		&RecoverMiddleware{
+			EnableResponseStackTrace: self.EnableResponseStackTrace,
+			Logger:                   self.Logger,
+			ErrorHandler:             self.ErrorHandler,
+		},
+	}
 
-func (self *ResourceHandler) logResponseRecord(record *responseLogRecord) {
 	if self.EnableLogAsJson {
-		b, err := json.Marshal(record)
-		if err != nil {
-			panic(err)
-		}
-		self.Logger.Printf("%s", b)
+		middlewares = append(middlewares, &AccessLogJsonMiddleware{Logger: self.Logger})
 	} else {
-		self.Logger.Printf("%d %v %s %s",
-			record.StatusCode,
-			record.ResponseTime,
-			record.HttpMethod,
-			record.RequestURI,
-		)
+		middlewares = append(middlewares, &AccessLogApacheMiddleware{Logger: self.Logger})
 	}
+
+	if self.EnableStatusService {
+		middlewares = append(middlewares, &StatusMiddleware{statusService: self.statusService})
+	}
+
+	middlewares = append(middlewares, &TimerMiddleware{})
+
+	if self.EnableGzip {
+		middlewares = append(middlewares, &GzipMiddleware{})
+	}
+
+	if !self.DisableJsonIndent {
+		middlewares = append(middlewares, &JsonIndentMiddleware{})
+	}
+
+	middlewares = append(middlewares, self.PreRoutingMiddlewares...)
+
+	return wrapAll(self.dispatch, middlewares)
 }
 
-func (self *ResourceHandler) logResponse(statusCode int, start *time.Time, request *http.Request) {
+// Find the Route matching the request, and run it wrapped in its own
+// Route.Middlewares. Produces a 404 or 405 ErrorResponse (via handleError)
+// when no Route matches, or the 406/503 ErrorResponse decided earlier by
+// ServeHTTP, so that either way the built-in and PreRoutingMiddlewares see
+// a consistent response to log and count.
+func (self *ResourceHandler) dispatch(writer *ResponseWriter, request *Request) {
 
-	now := time.Now()
-	duration := now.Sub(*start)
+	if request.preDispatchErr != nil {
+		self.handleError(writer, request, request.preDispatchErr, request.preDispatchCode)
+		return
+	}
 
-	if self.statusService != nil {
-		self.statusService.update(statusCode, &duration)
+	route, params, pathMatched := self.internalRouter.findRouteFromURL(request.Method, request.URL)
+	if route == nil {
+		if pathMatched {
+			// no route found, but path was matched: 405 Method Not Allowed
+			self.handleError(writer, request, fmt.Errorf("Method not allowed"), http.StatusMethodNotAllowed)
+		} else {
+			// no route found, the path was not matched: 404 Not Found
+			self.handleError(writer, request, fmt.Errorf("Resource not found"), http.StatusNotFound)
+		}
+		return
 	}
 
-	self.logResponseRecord(&responseLogRecord{
-		statusCode,
-		&duration,
-		request.Method,
-		request.URL.RequestURI(),
-	})
+	// a route was found, set the PathParams
+	request.PathParams = params
+
+	handler := wrapAll(HandlerFunc(route.Func), route.Middlewares)
+	handler(writer, request)
 }
 
 // This makes ResourceHandler implement the http.Handler interface.
 // You probably don't want to use it directly.
 func (self *ResourceHandler) ServeHTTP(origWriter http.ResponseWriter, origRequest *http.Request) {
 
-	start := time.Now()
-
 	// set a default Logger
 	if self.Logger == nil {
 		self.Logger = log.New(os.Stderr, "", log.LstdFlags)
 	}
 
-	// catch user code's panic, and convert to http response
-	// (this does not use the JSON error response on purpose)
-	defer func() {
-		if reco := recover(); reco != nil {
-			trace := debug.Stack()
-
-			// log the trace
-			self.Logger.Printf("%s\n%s", reco, trace)
-
-			// write error response
-			message := "Internal Server Error"
-			if self.EnableResponseStackTrace {
-				message = fmt.Sprintf("%s\n\n%s", reco, trace)
-			}
-			http.Error(origWriter, message, http.StatusInternalServerError)
-
-			// log response
-			self.logResponse(
-				http.StatusInternalServerError,
-				&start,
-				origRequest,
-			)
-		}
-	}()
-
-	request := Request{
-		origRequest,
-		nil,
+	codecs := self.Codecs
+	if len(codecs) == 0 {
+		codecs = DefaultCodecs
 	}
 
-	// determine if gzip is needed
-	isGzipped := self.EnableGzip == true &&
-		strings.Contains(origRequest.Header.Get("Accept-Encoding"), "gzip")
+	writer := &ResponseWriter{
+		ResponseWriter: origWriter,
+	}
 
 	isIndented := !self.DisableJsonIndent
 
-	writer := ResponseWriter{
-		origWriter,
-		isGzipped,
-		isIndented,
-		0,
-		false,
-	}
+	// A 406 or 503 here still has to flow through buildHandlerStack like
+	// any other response, so PreRoutingMiddlewares and the built-in
+	// Recover/AccessLog/Status middlewares see it instead of being
+	// bypassed. dispatch renders preDispatchErr once routing is reached.
+	var preDispatchErr error
+	var preDispatchCode int
+
+	encodeCodec := negotiateCodec(origRequest.Header.Get("Accept"), codecs)
+	if encodeCodec == nil {
+		writer.codec = &jsonCodec{indent: isIndented}
+		preDispatchErr = fmt.Errorf("Not Acceptable")
+		preDispatchCode = http.StatusNotAcceptable
+	} else {
+		if encodeCodec.MediaType() == jsonMediaType && isIndented {
+			encodeCodec = &jsonCodec{indent: true}
+		}
+		writer.codec = encodeCodec
 
-	// find the route
-	route, params, pathMatched := self.internalRouter.findRouteFromURL(origRequest.Method, origRequest.URL)
-	if route == nil {
-		if pathMatched {
-			// no route found, but path was matched: 405 Method Not Allowed
-			Error(&writer, "Method not allowed", http.StatusMethodNotAllowed)
-
-			// log response
-			self.logResponse(
-				http.StatusMethodNotAllowed,
-				&start,
-				origRequest,
-			)
-			return
+		if !self.beginRequest() {
+			// Shutdown has been called, refuse new requests.
+			preDispatchErr = fmt.Errorf("Service Unavailable")
+			preDispatchCode = http.StatusServiceUnavailable
 		} else {
-			// no route found, the path was not matched: 404 Not Found
-			NotFound(&writer, &request)
-
-			// log response
-			self.logResponse(
-				http.StatusNotFound,
-				&start,
-				origRequest,
-			)
-			return
+			defer self.activeRequests.Done()
 		}
 	}
 
-	// a route was found, set the PathParams
-	request.PathParams = params
+	decodeCodec := negotiateCodec(origRequest.Header.Get("Content-Type"), codecs)
+	if decodeCodec == nil {
+		decodeCodec = codecs[0]
+	}
 
-	// run the user code
-	handler := route.Func
-	handler(&writer, &request)
+	ctx, cancel := self.requestContext(origWriter, origRequest)
+	defer cancel()
+
+	request := &Request{
+		Request:         origRequest,
+		PathParams:      nil,
+		Env:             map[string]interface{}{},
+		codec:           decodeCodec,
+		router:          self.internalRouter,
+		ctx:             ctx,
+		preDispatchErr:  preDispatchErr,
+		preDispatchCode: preDispatchCode,
+	}
 
-	// log response
-	self.logResponse(
-		writer.statusCode,
-		&start,
-		origRequest,
-	)
+	handler := self.buildHandlerStack()
+	handler(writer, request)
 }
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"context"
+	"launchpad.net/gocheck"
+	"testing"
+
+	"github.com/ant0ine/go-json-rest/test"
+)
+
+func Test(t *testing.T) { gocheck.TestingT(t) }
+
+type HandlerSuite struct{}
+
+var _ = gocheck.Suite(&HandlerSuite{})
+
+func (s *HandlerSuite) TestRouting(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(
+		Route{
+			HttpMethod: "GET",
+			PathExp:    "/users/:id",
+			Func: func(w *ResponseWriter, r *Request) {
+				w.WriteJson(map[string]string{"id": r.PathParam("id")})
+			},
+		},
+	)
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/users/42", nil))
+	recorded.CodeIs(c, 200)
+	recorded.ContentTypeIsJson(c)
+
+	recorded = test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/nowhere", nil))
+	recorded.CodeIs(c, 404)
+
+	recorded = test.RunRequest(&handler, test.MakeSimpleRequest("POST", "http://example.com/users/42", nil))
+	recorded.CodeIs(c, 405)
+}
+
+func (s *HandlerSuite) TestNotAcceptable(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(
+		Route{
+			HttpMethod: "GET",
+			PathExp:    "/users/:id",
+			Func: func(w *ResponseWriter, r *Request) {
+				w.WriteJson(map[string]string{"id": r.PathParam("id")})
+			},
+		},
+	)
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/users/42", nil)
+	request.Header.Set("Accept", "text/plain")
+	recorded := test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 406)
+}
+
+func (s *HandlerSuite) TestShutdownRefusesNewRequests(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(
+		Route{
+			HttpMethod: "GET",
+			PathExp:    "/users/:id",
+			Func: func(w *ResponseWriter, r *Request) {
+				w.WriteJson(map[string]string{"id": r.PathParam("id")})
+			},
+		},
+	)
+
+	err := handler.Shutdown(context.Background())
+	c.Assert(err, gocheck.IsNil)
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/users/42", nil))
+	recorded.CodeIs(c, 503)
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"launchpad.net/gocheck"
+
+	"github.com/ant0ine/go-json-rest/test"
+)
+
+type HateoasSuite struct{}
+
+var _ = gocheck.Suite(&HateoasSuite{})
+
+func (s *HateoasSuite) TestAddLinkAndURLFor(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/users/:id",
+		Name:       "get_user",
+		Func: func(w *ResponseWriter, r *Request) {
+			self, err := r.URLFor("get_user", map[string]string{"id": r.PathParam("id")})
+			c.Assert(err, gocheck.IsNil)
+			w.AddLink("self", self.String())
+			w.WriteJson(map[string]string{"id": r.PathParam("id")})
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/users/42", nil))
+	recorded.CodeIs(c, 200)
+	recorded.HeaderIs(c, "Link", `<http://example.com/users/42>; rel="self"`)
+}
+
+func (s *HateoasSuite) TestAddLinkWithParam(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/users/:id",
+		Func: func(w *ResponseWriter, r *Request) {
+			w.AddLink("next", "http://example.com/users/43", LinkParam{Key: "title", Value: "next page"})
+			w.WriteJson(map[string]string{"id": r.PathParam("id")})
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/users/42", nil))
+	recorded.HeaderIs(c, "Link", `<http://example.com/users/43>; rel="next"; title="next page"`)
+}
+
+func (s *HateoasSuite) TestURLForUnknownRoute(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/users/:id",
+		Func: func(w *ResponseWriter, r *Request) {
+			_, err := r.URLFor("no_such_route", nil)
+			c.Assert(err == nil, gocheck.Equals, false)
+			w.WriteJson(map[string]string{"id": r.PathParam("id")})
+		},
+	})
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/users/42", nil))
+	recorded.CodeIs(c, 200)
+}
+
+func (s *HateoasSuite) TestBaseURLHonorsForwardedProto(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(Route{
+		HttpMethod: "GET",
+		PathExp:    "/users/:id",
+		Func: func(w *ResponseWriter, r *Request) {
+			w.WriteJson(map[string]string{"base": r.BaseURL().String()})
+		},
+	})
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/users/42", nil)
+	request.Header.Set("X-Forwarded-Proto", "https")
+	recorded := test.RunRequest(&handler, request)
+
+	var payload map[string]string
+	err := recorded.DecodeJsonPayload(&payload)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(payload["base"], gocheck.Equals, "https://example.com")
+}
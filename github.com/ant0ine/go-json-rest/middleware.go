@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// The signature used for Route.Func and for the handler passed in and out
+// of a Middleware.
+type HandlerFunc func(*ResponseWriter, *Request)
+
+// A Middleware wraps a HandlerFunc and returns the wrapped HandlerFunc,
+// typically doing some work before and/or after calling it.
+type Middleware interface {
+	MiddlewareFunc(handler HandlerFunc) HandlerFunc
+}
+
+// MiddlewareFunc lets a plain func be used where a Middleware is expected.
+type MiddlewareFunc func(handler HandlerFunc) HandlerFunc
+
+func (self MiddlewareFunc) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return self(handler)
+}
+
+// wrapAll chains middlewares around handler, middlewares[0] being the
+// outermost one (the first to run).
+func wrapAll(handler HandlerFunc, middlewares []Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].MiddlewareFunc(handler)
+	}
+	return handler
+}
+
+// Key used in Request.Env to stash the time spent in the wrapped handler,
+// set by TimerMiddleware and read by the AccessLog middlewares.
+const envElapsedTime = "ELAPSED_TIME"
+
+// RecoverMiddleware catches panics in the wrapped handler, logs the error
+// and the stack trace, and converts them into a 500 JSON ErrorResponse.
+type RecoverMiddleware struct {
+
+	// If true, the stack trace is attached at Details["stack"] in the
+	// response body.
+	EnableResponseStackTrace bool
+
+	// Custom logger, defaults to log.New(os.Stderr, "", log.LstdFlags)
+	Logger *log.Logger
+
+	// Called instead of defaultErrorHandler to render the 500 response.
+	// Set from ResourceHandler.ErrorHandler by buildHandlerStack.
+	ErrorHandler func(*ResponseWriter, *Request, error, int)
+}
+
+func (self *RecoverMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		defer func() {
+			if reco := recover(); reco != nil {
+				trace := debug.Stack()
+
+				logger := self.Logger
+				if logger == nil {
+					logger = log.New(os.Stderr, "", log.LstdFlags)
+				}
+				logger.Printf("%s\n%s", reco, trace)
+
+				if self.EnableResponseStackTrace {
+					request.Env[envStackTrace] = fmt.Sprintf("%s\n\n%s", reco, trace)
+				}
+
+				errorHandler := self.ErrorHandler
+				if errorHandler == nil {
+					errorHandler = defaultErrorHandler
+				}
+				errorHandler(writer, request, fmt.Errorf("%v", reco), http.StatusInternalServerError)
+			}
+		}()
+		handler(writer, request)
+	}
+}
+
+// TimerMiddleware records how long the wrapped handler took, and stashes it
+// in Request.Env for the AccessLog middlewares to pick up.
+type TimerMiddleware struct{}
+
+func (self *TimerMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		start := time.Now()
+		handler(writer, request)
+		request.Env[envElapsedTime] = time.Now().Sub(start)
+	}
+}
+
+// GzipMiddleware transparently gzips the response body when the client
+// advertises support for it via the Accept-Encoding header.
+type GzipMiddleware struct{}
+
+func (self *GzipMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		if strings.Contains(request.Header.Get("Accept-Encoding"), "gzip") {
+			writer.isGzipped = true
+		}
+		handler(writer, request)
+	}
+}
+
+// JsonIndentMiddleware makes WriteJson indent its output, which is handy
+// for development but usually turned off in production.
+type JsonIndentMiddleware struct{}
+
+func (self *JsonIndentMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		writer.isIndented = true
+		handler(writer, request)
+	}
+}
+
+// StatusMiddleware feeds the ResourceHandler's statusService, exposed at
+// GET /.status, with the status code and response time of every request.
+type StatusMiddleware struct {
+	statusService *statusService
+}
+
+func (self *StatusMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		start := time.Now()
+		handler(writer, request)
+		duration := time.Now().Sub(start)
+		self.statusService.update(writer.statusCode, &duration)
+	}
+}
+
+type accessLogRecord struct {
+	StatusCode   int
+	ResponseTime string
+	HttpMethod   string
+	RequestURI   string
+}
+
+func elapsedTime(request *Request, fallback time.Time) time.Duration {
+	if elapsed, ok := request.Env[envElapsedTime].(time.Duration); ok {
+		return elapsed
+	}
+	return time.Now().Sub(fallback)
+}
+
+// AccessLogApacheMiddleware logs one line per request/response, in the
+// traditional "status duration method uri" format.
+type AccessLogApacheMiddleware struct {
+
+	// Custom logger, defaults to log.New(os.Stderr, "", log.LstdFlags)
+	Logger *log.Logger
+}
+
+func (self *AccessLogApacheMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		start := time.Now()
+		handler(writer, request)
+
+		logger := self.Logger
+		if logger == nil {
+			logger = log.New(os.Stderr, "", log.LstdFlags)
+		}
+		logger.Printf("%d %v %s %s",
+			writer.statusCode,
+			elapsedTime(request, start),
+			request.Method,
+			request.URL.RequestURI(),
+		)
+	}
+}
+
+// AccessLogJsonMiddleware is the same as AccessLogApacheMiddleware, but logs
+// one JSON object per line, which is more convenient for log parsing.
+type AccessLogJsonMiddleware struct {
+
+	// Custom logger, defaults to log.New(os.Stderr, "", log.LstdFlags)
+	Logger *log.Logger
+}
+
+func (self *AccessLogJsonMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		start := time.Now()
+		handler(writer, request)
+
+		record := &accessLogRecord{
+			StatusCode:   writer.statusCode,
+			ResponseTime: elapsedTime(request, start).String(),
+			HttpMethod:   request.Method,
+			RequestURI:   request.URL.RequestURI(),
+		}
+		b, err := json.Marshal(record)
+		if err != nil {
+			panic(err)
+		}
+
+		logger := self.Logger
+		if logger == nil {
+			logger = log.New(os.Stderr, "", log.LstdFlags)
+		}
+		logger.Printf("%s", b)
+	}
+}
+
+// CorsMiddleware adds the Access-Control-* response headers needed for
+// cross-origin requests, and short-circuits CORS preflight OPTIONS requests.
+type CorsMiddleware struct {
+
+	// Origins allowed to access the resource, "*" matches any origin.
+	AllowedOrigins []string
+
+	// Methods advertised in the preflight response.
+	AllowedMethods []string
+
+	// Headers advertised in the preflight response.
+	AllowedHeaders []string
+}
+
+func (self *CorsMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		origin := request.Header.Get("Origin")
+		if origin != "" && self.originAllowed(origin) {
+			writer.Header().Set("Access-Control-Allow-Origin", origin)
+			if len(self.AllowedMethods) > 0 {
+				writer.Header().Set("Access-Control-Allow-Methods", strings.Join(self.AllowedMethods, ", "))
+			}
+			if len(self.AllowedHeaders) > 0 {
+				writer.Header().Set("Access-Control-Allow-Headers", strings.Join(self.AllowedHeaders, ", "))
+			}
+		}
+
+		if request.Method == "OPTIONS" {
+			writer.WriteHeader(http.StatusOK)
+			return
+		}
+
+		handler(writer, request)
+	}
+}
+
+func (self *CorsMiddleware) originAllowed(origin string) bool {
+	for _, allowed := range self.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthBasicMiddleware protects the wrapped handler with HTTP Basic auth,
+// delegating the userId/password check to Authenticator.
+type AuthBasicMiddleware struct {
+
+	// Realm name presented to clients in the WWW-Authenticate header.
+	Realm string
+
+	// Called on every request with the credentials found in the
+	// Authorization header, return true to let the request through.
+	Authenticator func(userId string, password string) bool
+}
+
+func (self *AuthBasicMiddleware) MiddlewareFunc(handler HandlerFunc) HandlerFunc {
+	return func(writer *ResponseWriter, request *Request) {
+		userId, password, err := parseBasicAuth(request.Header.Get("Authorization"))
+		if err != nil || !self.Authenticator(userId, password) {
+			writer.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", self.Realm))
+			Error(writer, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+func parseBasicAuth(header string) (userId string, password string, err error) {
+	if !strings.HasPrefix(header, "Basic ") {
+		return "", "", fmt.Errorf("invalid or missing Authorization header")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Basic "))
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid Authorization header")
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"launchpad.net/gocheck"
+
+	"github.com/ant0ine/go-json-rest/test"
+)
+
+type MiddlewareSuite struct{}
+
+var _ = gocheck.Suite(&MiddlewareSuite{})
+
+func pingRoute() Route {
+	return Route{
+		HttpMethod: "GET",
+		PathExp:    "/ping",
+		Func: func(w *ResponseWriter, r *Request) {
+			w.WriteJson(map[string]string{"ping": "pong"})
+		},
+	}
+}
+
+func (s *MiddlewareSuite) TestCorsMiddleware(c *gocheck.C) {
+	route := pingRoute()
+	route.Middlewares = []Middleware{
+		&CorsMiddleware{AllowedOrigins: []string{"http://allowed.example.com"}},
+	}
+	handler := ResourceHandler{}
+	handler.SetRoutes(route)
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/ping", nil)
+	request.Header.Set("Origin", "http://allowed.example.com")
+	recorded := test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+	recorded.HeaderIs(c, "Access-Control-Allow-Origin", "http://allowed.example.com")
+
+	request = test.MakeSimpleRequest("GET", "http://example.com/ping", nil)
+	request.Header.Set("Origin", "http://evil.example.com")
+	recorded = test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+	recorded.HeaderIs(c, "Access-Control-Allow-Origin", "")
+}
+
+func (s *MiddlewareSuite) TestAuthBasicMiddleware(c *gocheck.C) {
+	route := pingRoute()
+	route.Middlewares = []Middleware{
+		&AuthBasicMiddleware{
+			Realm: "test",
+			Authenticator: func(userId, password string) bool {
+				return userId == "admin" && password == "secret"
+			},
+		},
+	}
+	handler := ResourceHandler{}
+	handler.SetRoutes(route)
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/ping", nil))
+	recorded.CodeIs(c, 401)
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/ping", nil)
+	request.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret")))
+	recorded = test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+}
+
+func (s *MiddlewareSuite) TestGzipMiddleware(c *gocheck.C) {
+	handler := ResourceHandler{EnableGzip: true, DisableJsonIndent: true}
+	handler.SetRoutes(pingRoute())
+
+	request := test.MakeSimpleRequest("GET", "http://example.com/ping", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorded := test.RunRequest(&handler, request)
+	recorded.CodeIs(c, 200)
+	recorded.HeaderIs(c, "Content-Encoding", "gzip")
+
+	reader, err := gzip.NewReader(recorded.Recorder.Body)
+	c.Assert(err, gocheck.IsNil)
+	body, err := ioutil.ReadAll(reader)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(string(body), gocheck.Equals, "{\"ping\":\"pong\"}")
+}
+
+func (s *MiddlewareSuite) TestJsonIndentMiddleware(c *gocheck.C) {
+	handler := ResourceHandler{}
+	handler.SetRoutes(pingRoute())
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/ping", nil))
+	recorded.BodyIs(c, "{\n  \"ping\": \"pong\"\n}")
+
+	handler = ResourceHandler{DisableJsonIndent: true}
+	handler.SetRoutes(pingRoute())
+	recorded = test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/ping", nil))
+	recorded.BodyIs(c, "{\"ping\":\"pong\"}")
+}
+
+func (s *MiddlewareSuite) TestStatusService(c *gocheck.C) {
+	handler := ResourceHandler{EnableStatusService: true}
+	handler.SetRoutes(pingRoute())
+
+	test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/ping", nil))
+
+	recorded := test.RunRequest(&handler, test.MakeSimpleRequest("GET", "http://example.com/.status", nil))
+	recorded.CodeIs(c, 200)
+
+	var payload status
+	err := recorded.DecodeJsonPayload(&payload)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(payload.TotalCount >= int64(1), gocheck.Equals, true)
+}
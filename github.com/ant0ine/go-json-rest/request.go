@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Inherit from http.Request, and provide additional methods.
+type Request struct {
+	*http.Request
+
+	// Map of parameters that have been matched in the URL Path.
+	PathParams map[string]string
+
+	// Bag of data to pass down the Middleware chain, used by the built-in
+	// middlewares to stash things like the route name or the request timer.
+	Env map[string]interface{}
+
+	// Codec selected from ResourceHandler.Codecs by matching the
+	// Content-Type header, used by ReadEntity.
+	codec Codec
+
+	// The ResourceHandler's router, used by URLFor to reverse-resolve
+	// named Routes.
+	router *router
+
+	// Cancelled on client disconnect, ResourceHandler.Shutdown, or
+	// HandlerTimeout, whichever comes first. See Context.
+	ctx context.Context
+
+	// Set by ServeHTTP when content negotiation or Shutdown already
+	// decided the response (406 or 503) before routing was reached.
+	// Picked up by dispatch so the decision still flows through the
+	// Middleware stack instead of bypassing it.
+	preDispatchErr  error
+	preDispatchCode int
+}
+
+// Return the Context for this request, to be passed down to anything that
+// should stop working when the client disconnects, the ResourceHandler is
+// shutting down, or HandlerTimeout elapses. This shadows the Context method
+// promoted from the embedded *http.Request with one that also reacts to
+// those two additional cases.
+func (self *Request) Context() context.Context {
+	return self.ctx
+}
+
+// Return the matching parameter found in the URL. Get the last value when
+// multiple params have the same name.
+func (self *Request) PathParam(name string) string {
+	return self.PathParams[name]
+}
+
+// Read the request body and decode the JSON using json.Unmarshal.
+func (self *Request) DecodeJsonPayload(v interface{}) error {
+	defer self.Body.Close()
+	content, err := ioutil.ReadAll(self.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// Read the request body and decode it using the Codec picked for the
+// Content-Type header, defaulting to JSON. This is the content-negotiation
+// aware equivalent of DecodeJsonPayload.
+func (self *Request) ReadEntity(v interface{}) error {
+	defer self.Body.Close()
+	return self.codec.Decode(self.Body, v)
+}
+
+// Return the scheme and host this Request was made to, honoring
+// X-Forwarded-Proto so it still works behind a reverse proxy. Meant to be
+// combined with URLFor to build absolute links.
+func (self *Request) BaseURL() *url.URL {
+	scheme := "http"
+	if self.TLS != nil {
+		scheme = "https"
+	}
+	if forwardedProto := self.Header.Get("X-Forwarded-Proto"); forwardedProto != "" {
+		scheme = forwardedProto
+	}
+	return &url.URL{
+		Scheme: scheme,
+		Host:   self.Host,
+	}
+}
+
+// Build the absolute URL of the Route registered under routeName, with
+// params substituted for its :param and *splat placeholders. This is the
+// basis for HATEOAS style links, see also ResponseWriter.AddLink.
+func (self *Request) URLFor(routeName string, params map[string]string) (*url.URL, error) {
+	if self.router == nil {
+		return nil, fmt.Errorf("rest: no router available for URLFor")
+	}
+	path, err := self.router.pathFor(routeName, params)
+	if err != nil {
+		return nil, err
+	}
+	resolved := self.BaseURL()
+	resolved.Path = path
+	return resolved, nil
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Inherit from http.ResponseWriter, and provide additional methods.
+type ResponseWriter struct {
+	http.ResponseWriter
+	isGzipped   bool
+	isIndented  bool
+	statusCode  int
+	wroteHeader bool
+
+	// Codec selected from ResourceHandler.Codecs by matching the Accept
+	// header, used by WriteEntity.
+	codec Codec
+}
+
+// Use json.Marshal (or json.MarshalIndent) to write v to the ResponseWriter,
+// and set the Content-Type header.
+func (self *ResponseWriter) WriteJson(v interface{}) error {
+	b, err := self.EncodeJson(v)
+	if err != nil {
+		return err
+	}
+	self.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, err = self.Write(b)
+	return err
+}
+
+// Encode the data structure to JSON, respecting the DisableJsonIndent option.
+func (self *ResponseWriter) EncodeJson(v interface{}) ([]byte, error) {
+	if self.isIndented {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return json.Marshal(v)
+}
+
+// Encode v using the negotiated Codec, and set the Content-Type header
+// accordingly. This is the content-negotiation aware equivalent of WriteJson.
+func (self *ResponseWriter) WriteEntity(v interface{}) error {
+	self.Header().Set("Content-Type", self.codec.MediaType()+"; charset=utf-8")
+	return self.codec.Encode(self, v)
+}
+
+// An extra parameter of a Web Linking (RFC 5988) relation, e.g. {"title", "next page"}.
+type LinkParam struct {
+	Key   string
+	Value string
+}
+
+// Add a Web Linking (RFC 5988) relation to the response's Link header,
+// e.g. AddLink("next", someURL.String(), LinkParam{"title", "next page"}).
+// Can be called multiple times to advertise several relations.
+func (self *ResponseWriter) AddLink(rel, href string, params ...LinkParam) {
+	value := fmt.Sprintf(`<%s>; rel=%q`, href, rel)
+	for _, param := range params {
+		value += fmt.Sprintf(`; %s=%q`, param.Key, param.Value)
+	}
+	self.Header().Add("Link", value)
+}
+
+// Record the status code for the access log, and call the underlying WriteHeader.
+func (self *ResponseWriter) WriteHeader(code int) {
+	self.statusCode = code
+	self.wroteHeader = true
+	if self.isGzipped {
+		self.Header().Set("Content-Encoding", "gzip")
+	}
+	self.ResponseWriter.WriteHeader(code)
+}
+
+// Transparently gzip the response body when the client accepts it.
+func (self *ResponseWriter) Write(b []byte) (int, error) {
+	if !self.wroteHeader {
+		self.WriteHeader(http.StatusOK)
+	}
+	if self.isGzipped {
+		gzipWriter := gzip.NewWriter(self.ResponseWriter)
+		defer gzipWriter.Close()
+		return gzipWriter.Write(b)
+	}
+	return self.ResponseWriter.Write(b)
+}
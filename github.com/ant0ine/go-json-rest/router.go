@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Dispatch the incoming requests to the matching Route, using a Trie based
+// implementation for efficient lookup.
+type router struct {
+	routes []Route
+	index  *trie
+	byName map[string]*Route
+}
+
+func (self *router) start() error {
+	self.index = newTrie()
+	self.byName = map[string]*Route{}
+	for i := range self.routes {
+		route := &self.routes[i]
+		if route.PathExp == "" || route.PathExp[0] != '/' {
+			return fmt.Errorf("PathExp must start with / (%s)", route.PathExp)
+		}
+		self.index.addRoute(route)
+		if route.Name != "" {
+			self.byName[route.Name] = route
+		}
+	}
+	return nil
+}
+
+// Return the Route matching the HttpMethod and URL, the extracted path
+// params, and whether the path itself was matched by at least one Route
+// (useful to distinguish 404 from 405).
+func (self *router) findRouteFromURL(httpMethod string, urlObj *url.URL) (*Route, map[string]string, bool) {
+	matches := self.index.findRoutes(urlObj.Path)
+
+	pathMatched := len(matches) > 0
+
+	for _, match := range matches {
+		if strings.ToUpper(match.route.HttpMethod) == strings.ToUpper(httpMethod) {
+			return match.route, match.params, pathMatched
+		}
+	}
+
+	return nil, nil, pathMatched
+}
+
+// Reverse-resolve the path of the Route registered under name, substituting
+// params for its :param and *splat placeholders. Used by Request.URLFor.
+func (self *router) pathFor(name string, params map[string]string) (string, error) {
+	route, found := self.byName[name]
+	if !found {
+		return "", fmt.Errorf("rest: no route named %q", name)
+	}
+
+	segments := splitPath(route.PathExp)
+	built := make([]string, len(segments))
+	for i, segment := range segments {
+		isParam, isSplat, paramName, literalSuffix := parsePathComponent(segment)
+		if !isParam && !isSplat {
+			built[i] = segment
+			continue
+		}
+		value, ok := params[paramName]
+		if !ok {
+			return "", fmt.Errorf("rest: missing param %q for route %q", paramName, name)
+		}
+		if literalSuffix != "" {
+			value += "." + literalSuffix
+		}
+		built[i] = value
+	}
+
+	return "/" + strings.Join(built, "/"), nil
+}
@@ -0,0 +1,20 @@
+package rest
+
+import "launchpad.net/gocheck"
+
+type RouterSuite struct{}
+
+var _ = gocheck.Suite(&RouterSuite{})
+
+func (s *RouterSuite) TestPathForDottedParam(c *gocheck.C) {
+	r := &router{
+		routes: []Route{
+			{HttpMethod: "GET", PathExp: "/resource/:id.json", Name: "resource"},
+		},
+	}
+	c.Assert(r.start(), gocheck.IsNil)
+
+	path, err := r.pathFor("resource", map[string]string{"id": "42"})
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(path, gocheck.Equals, "/resource/42.json")
+}
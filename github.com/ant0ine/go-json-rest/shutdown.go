@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+)
+
+// Shutdown makes ServeHTTP refuse any new request with a 503 Service
+// Unavailable, cancels the Context of every request currently in flight,
+// and blocks until they have all completed or ctx is done, whichever comes
+// first. Meant to be called once, typically right before closing the
+// http.Server that embeds self.
+func (self *ResourceHandler) Shutdown(ctx context.Context) error {
+	signal := self.ensureShutdownSignal()
+
+	self.mu.Lock()
+	if !self.shuttingDown {
+		self.shuttingDown = true
+		close(signal)
+	}
+	self.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		self.activeRequests.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (self *ResourceHandler) ensureShutdownSignal() chan struct{} {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.shutdownSignal == nil {
+		self.shutdownSignal = make(chan struct{})
+	}
+	return self.shutdownSignal
+}
+
+// Reserve a slot in activeRequests for a new request, or return false if
+// Shutdown has already been called.
+func (self *ResourceHandler) beginRequest() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.shuttingDown {
+		return false
+	}
+	self.activeRequests.Add(1)
+	return true
+}
+
+// Build the Context for one request, cancelled when the client disconnects
+// (detected via http.CloseNotifier), when Shutdown is called, or after
+// HandlerTimeout elapses, whichever comes first. The returned cancel func
+// must be called once the request is done to release its resources.
+func (self *ResourceHandler) requestContext(origWriter http.ResponseWriter, origRequest *http.Request) (context.Context, func()) {
+
+	ctx, cancel := context.WithCancel(origRequest.Context())
+
+	signal := self.ensureShutdownSignal()
+	go func() {
+		select {
+		case <-signal:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if closeNotifier, ok := origWriter.(http.CloseNotifier); ok {
+		go func() {
+			select {
+			case <-closeNotifier.CloseNotify():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if self.HandlerTimeout > 0 {
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, self.HandlerTimeout)
+		return timeoutCtx, func() {
+			timeoutCancel()
+			cancel()
+		}
+	}
+
+	return ctx, cancel
+}
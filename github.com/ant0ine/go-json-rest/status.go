@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Maintains simple stats about the requests served, exposed at GET /.status
+// when ResourceHandler.EnableStatusService is true.
+type statusService struct {
+	lock              sync.Mutex
+	start             time.Time
+	pid               int
+	totalCount        int64
+	totalResponseTime time.Duration
+	statusCodeCount   map[int]int64
+}
+
+type status struct {
+	Pid                    int
+	UpTime                 string
+	UpTimeSec              float64
+	Time                   string
+	TimeUnix               int64
+	StatusCodeCount        map[string]int64
+	TotalCount             int64
+	TotalResponseTime      string
+	TotalResponseTimeSec   float64
+	AverageResponseTime    string
+	AverageResponseTimeSec float64
+}
+
+func newStatusService() *statusService {
+	return &statusService{
+		start:           time.Now(),
+		pid:             os.Getpid(),
+		statusCodeCount: map[int]int64{},
+	}
+}
+
+func (self *statusService) update(statusCode int, responseTime *time.Duration) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.totalCount++
+	self.totalResponseTime += *responseTime
+	self.statusCodeCount[statusCode]++
+}
+
+func (self *statusService) getStatus(writer *ResponseWriter, request *Request) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := time.Now()
+	upTime := now.Sub(self.start)
+
+	codeCount := map[string]int64{}
+	for code, count := range self.statusCodeCount {
+		codeCount[strconv.Itoa(code)] = count
+	}
+
+	average := time.Duration(0)
+	if self.totalCount > 0 {
+		average = time.Duration(int64(self.totalResponseTime) / self.totalCount)
+	}
+
+	writer.WriteEntity(&status{
+		Pid:                    self.pid,
+		UpTime:                 upTime.String(),
+		UpTimeSec:              upTime.Seconds(),
+		Time:                   now.String(),
+		TimeUnix:               now.Unix(),
+		StatusCodeCount:        codeCount,
+		TotalCount:             self.totalCount,
+		TotalResponseTime:      self.totalResponseTime.String(),
+		TotalResponseTimeSec:   self.totalResponseTime.Seconds(),
+		AverageResponseTime:    average.String(),
+		AverageResponseTimeSec: average.Seconds(),
+	})
+}
@@ -0,0 +1,74 @@
+// Helpers to unit test a rest.ResourceHandler without spinning up a TCP
+// listener, using net/http/httptest.Recorder under the hood.
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"launchpad.net/gocheck"
+	"net/http"
+	"net/http/httptest"
+)
+
+// Build a simple request, encoding payload as the JSON body when provided.
+func MakeSimpleRequest(method string, urlStr string, payload interface{}) *http.Request {
+
+	var body *bytes.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			panic(err)
+		}
+		body = bytes.NewReader(b)
+	} else {
+		body = bytes.NewReader([]byte{})
+	}
+
+	request, err := http.NewRequest(method, urlStr, body)
+	if err != nil {
+		panic(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	return request
+}
+
+// Run the request through handler, without going through an actual TCP
+// connection, and record the response.
+func RunRequest(handler http.Handler, request *http.Request) *RecordedResponse {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return &RecordedResponse{recorder}
+}
+
+// Wraps a httptest.ResponseRecorder with assertions convenient for testing
+// a JSON API.
+type RecordedResponse struct {
+	Recorder *httptest.ResponseRecorder
+}
+
+// Assert the response status code.
+func (self *RecordedResponse) CodeIs(c *gocheck.C, expectedCode int) {
+	c.Assert(self.Recorder.Code, gocheck.Equals, expectedCode)
+}
+
+// Assert the Content-Type header is the one set by ResponseWriter.WriteJson.
+func (self *RecordedResponse) ContentTypeIsJson(c *gocheck.C) {
+	self.HeaderIs(c, "Content-Type", "application/json; charset=utf-8")
+}
+
+// Assert a response header value.
+func (self *RecordedResponse) HeaderIs(c *gocheck.C, headerKey, expectedValue string) {
+	c.Assert(self.Recorder.Header().Get(headerKey), gocheck.Equals, expectedValue)
+}
+
+// Assert the raw response body.
+func (self *RecordedResponse) BodyIs(c *gocheck.C, expectedBody string) {
+	c.Assert(self.Recorder.Body.String(), gocheck.Equals, expectedBody)
+}
+
+// Decode the response body as JSON into v.
+func (self *RecordedResponse) DecodeJsonPayload(v interface{}) error {
+	return json.Unmarshal(self.Recorder.Body.Bytes(), v)
+}
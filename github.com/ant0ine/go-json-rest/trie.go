@@ -0,0 +1,143 @@
+package rest
+
+import "strings"
+
+// A minimal Trie used to match URL paths against PathExp strings supporting
+// the :param and *splat placeholders.
+type trie struct {
+	root *trieNode
+}
+
+type trieNode struct {
+	component string
+	isParam   bool
+	isSplat   bool
+
+	// For isParam, the param name and the literal text, if any, that must
+	// follow the first '.' in the matched path component, as documented
+	// on Route.PathExp (e.g. ":id.json" has paramName "id" and
+	// literalSuffix "json"). Unused (empty) for isSplat and literal
+	// components.
+	paramName     string
+	literalSuffix string
+
+	children []*trieNode
+	routes   []*Route
+}
+
+// Parse one PathExp path component into its placeholder parts, per the
+// Route.PathExp doc: a :param matches any char to the first '/' or '.', a
+// *splat matches to the end of the string. Since components are already
+// split on '/', a :param's "first '.'" boundary falls within the
+// component itself, splitting it into paramName and literalSuffix.
+func parsePathComponent(component string) (isParam, isSplat bool, paramName, literalSuffix string) {
+	switch {
+	case strings.HasPrefix(component, ":"):
+		isParam = true
+		name := strings.TrimPrefix(component, ":")
+		if dot := strings.Index(name, "."); dot >= 0 {
+			paramName, literalSuffix = name[:dot], name[dot+1:]
+		} else {
+			paramName = name
+		}
+	case strings.HasPrefix(component, "*"):
+		isSplat = true
+		paramName = strings.TrimPrefix(component, "*")
+	}
+	return
+}
+
+func newTrie() *trie {
+	return &trie{root: &trieNode{}}
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (self *trie) addRoute(route *Route) {
+	node := self.root
+	for _, component := range splitPath(route.PathExp) {
+		node = node.child(component)
+	}
+	node.routes = append(node.routes, route)
+}
+
+func (self *trieNode) child(component string) *trieNode {
+	for _, child := range self.children {
+		if child.component == component {
+			return child
+		}
+	}
+	isParam, isSplat, paramName, literalSuffix := parsePathComponent(component)
+	child := &trieNode{
+		component:     component,
+		isParam:       isParam,
+		isSplat:       isSplat,
+		paramName:     paramName,
+		literalSuffix: literalSuffix,
+	}
+	self.children = append(self.children, child)
+	return child
+}
+
+// Walk the Trie for the given path, returning all the Routes whose PathExp
+// matches, along with the extracted path params.
+func (self *trie) findRoutes(path string) []*trieMatch {
+	matches := []*trieMatch{}
+	self.root.match(splitPath(path), map[string]string{}, &matches)
+	return matches
+}
+
+type trieMatch struct {
+	route  *Route
+	params map[string]string
+}
+
+func (self *trieNode) match(components []string, params map[string]string, matches *[]*trieMatch) {
+	if len(components) == 0 {
+		for _, route := range self.routes {
+			*matches = append(*matches, &trieMatch{route: route, params: copyParams(params)})
+		}
+		return
+	}
+
+	head, tail := components[0], components[1:]
+
+	for _, child := range self.children {
+		switch {
+		case child.isSplat:
+			newParams := copyParams(params)
+			newParams[child.paramName] = strings.Join(components, "/")
+			for _, route := range child.routes {
+				*matches = append(*matches, &trieMatch{route: route, params: copyParams(newParams)})
+			}
+		case child.isParam:
+			value := head
+			if child.literalSuffix != "" {
+				suffix := "." + child.literalSuffix
+				if !strings.HasSuffix(head, suffix) || len(head) == len(suffix) {
+					continue
+				}
+				value = strings.TrimSuffix(head, suffix)
+			}
+			newParams := copyParams(params)
+			newParams[child.paramName] = value
+			child.match(tail, newParams, matches)
+		case child.component == head:
+			child.match(tail, params, matches)
+		}
+	}
+}
+
+func copyParams(params map[string]string) map[string]string {
+	newParams := make(map[string]string, len(params))
+	for k, v := range params {
+		newParams[k] = v
+	}
+	return newParams
+}
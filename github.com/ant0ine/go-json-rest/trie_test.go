@@ -0,0 +1,20 @@
+package rest
+
+import "launchpad.net/gocheck"
+
+type TrieSuite struct{}
+
+var _ = gocheck.Suite(&TrieSuite{})
+
+func (s *TrieSuite) TestDottedParam(c *gocheck.C) {
+	tr := newTrie()
+	route := &Route{HttpMethod: "GET", PathExp: "/resource/:id.json"}
+	tr.addRoute(route)
+
+	matches := tr.findRoutes("/resource/42.json")
+	c.Assert(len(matches), gocheck.Equals, 1)
+	c.Assert(matches[0].params["id"], gocheck.Equals, "42")
+
+	c.Assert(len(tr.findRoutes("/resource/42.xml")), gocheck.Equals, 0)
+	c.Assert(len(tr.findRoutes("/resource/42")), gocheck.Equals, 0)
+}